@@ -0,0 +1,413 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/model/components"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// containerdConfig builds the containerd config.toml (schema version 2) from the typed
+// kops.ContainerdConfig API fields. It intentionally only models the handful of plugin
+// sections kops knows how to configure; anything else belongs in ConfigOverride.
+type containerdConfig struct {
+	Version  int                      `toml:"version"`
+	Root     string                   `toml:"root,omitempty"`
+	State    string                   `toml:"state,omitempty"`
+	OOMScore int                      `toml:"oom_score,omitempty"`
+	GRPC     *containerdGRPCConfig    `toml:"grpc,omitempty"`
+	Metrics  *containerdMetricsConfig `toml:"metrics,omitempty"`
+	Plugins  containerdPluginsConfig  `toml:"plugins"`
+}
+
+type containerdGRPCConfig struct {
+	Address        string `toml:"address,omitempty"`
+	MaxRecvMsgSize int    `toml:"max_recv_message_size,omitempty"`
+	MaxSendMsgSize int    `toml:"max_send_message_size,omitempty"`
+}
+
+type containerdMetricsConfig struct {
+	Address string `toml:"address,omitempty"`
+}
+
+type containerdPluginsConfig struct {
+	CRI containerdCRIPluginConfig  `toml:"io.containerd.grpc.v1.cri"`
+	NRI *containerdNRIPluginConfig `toml:"io.containerd.nri.v1.nri,omitempty"`
+}
+
+type containerdCRIPluginConfig struct {
+	SandboxImage            string                        `toml:"sandbox_image,omitempty"`
+	MaxContainerLogLineSize int                           `toml:"max_container_log_line_size,omitempty"`
+	EnableCDI               bool                          `toml:"enable_cdi,omitempty"`
+	CDISpecDirs             []string                      `toml:"cdi_spec_dirs,omitempty"`
+	Containerd              containerdCRIContainerdConfig `toml:"containerd"`
+	Registry                containerdCRIRegistryConfig   `toml:"registry"`
+	CNI                     containerdCRICNIConfig        `toml:"cni,omitempty"`
+}
+
+// containerdNRIPluginConfig configures the Node Resource Interface plugin, which lets
+// external agents (device plugins, policy engines, ...) adjust container specs before they
+// reach the runtime. The section is only rendered when NRI is enabled, and then sets
+// disable=false explicitly; when NRI is not enabled it is omitted entirely.
+type containerdNRIPluginConfig struct {
+	Disable    bool   `toml:"disable"`
+	SocketPath string `toml:"socket_path,omitempty"`
+	PluginPath string `toml:"plugin_path,omitempty"`
+}
+
+type containerdCRICNIConfig struct {
+	ConfTemplate string `toml:"conf_template,omitempty"`
+}
+
+type containerdCRIContainerdConfig struct {
+	Snapshotter        string                                `toml:"snapshotter,omitempty"`
+	DefaultRuntimeName string                                `toml:"default_runtime_name,omitempty"`
+	Runtimes           map[string]containerdCRIRuntimeConfig `toml:"runtimes,omitempty"`
+}
+
+type containerdCRIRuntimeConfig struct {
+	RuntimeType    string                 `toml:"runtime_type"`
+	RuntimeRoot    string                 `toml:"runtime_root,omitempty"`
+	PodAnnotations []string               `toml:"pod_annotations,omitempty"`
+	Snapshotter    string                 `toml:"snapshotter,omitempty"`
+	Options        map[string]interface{} `toml:"options,omitempty"`
+}
+
+type containerdCRIRegistryConfig struct {
+	Mirrors map[string]containerdCRIRegistryMirrorConfig `toml:"mirrors,omitempty"`
+	Configs map[string]containerdCRIRegistryHostConfig   `toml:"configs,omitempty"`
+}
+
+type containerdCRIRegistryMirrorConfig struct {
+	Endpoint []string `toml:"endpoint"`
+}
+
+type containerdCRIRegistryHostConfig struct {
+	TLS  *containerdCRIRegistryTLSConfig  `toml:"tls,omitempty"`
+	Auth *containerdCRIRegistryAuthConfig `toml:"auth,omitempty"`
+}
+
+type containerdCRIRegistryTLSConfig struct {
+	CAFile             string `toml:"ca_file,omitempty"`
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify,omitempty"`
+}
+
+type containerdCRIRegistryAuthConfig struct {
+	Username string `toml:"username,omitempty"`
+	Password string `toml:"password,omitempty"`
+}
+
+// buildContainerdConfig renders /etc/containerd/config-kops.toml from the cluster's
+// ContainerdConfig, falling back to the sandbox image and cni config path kubenet expects.
+func (b *ContainerdBuilder) buildContainerdConfig() (string, error) {
+	containerd := kops.ContainerdConfig{}
+	if b.Cluster.Spec.Containerd != nil {
+		containerd = *b.Cluster.Spec.Containerd
+	}
+
+	cfg := containerdConfig{
+		Version: 2,
+		Root:    fi.StringValue(containerd.Root),
+		State:   fi.StringValue(containerd.State),
+		Plugins: containerdPluginsConfig{
+			CRI: containerdCRIPluginConfig{
+				SandboxImage:            b.sandboxImage(),
+				MaxContainerLogLineSize: int(fi.Int32Value(containerd.MaxContainerLogLineSize)),
+				Containerd: containerdCRIContainerdConfig{
+					Snapshotter: fi.StringValue(containerd.Snapshotter),
+				},
+			},
+		},
+	}
+
+	if containerd.Address != nil {
+		cfg.GRPC = &containerdGRPCConfig{Address: *containerd.Address}
+	}
+	if containerd.GRPCMaxRecvMsgSize != nil || containerd.GRPCMaxSendMsgSize != nil {
+		if cfg.GRPC == nil {
+			cfg.GRPC = &containerdGRPCConfig{}
+		}
+		cfg.GRPC.MaxRecvMsgSize = int(fi.Int32Value(containerd.GRPCMaxRecvMsgSize))
+		cfg.GRPC.MaxSendMsgSize = int(fi.Int32Value(containerd.GRPCMaxSendMsgSize))
+	}
+
+	if containerd.MetricsAddress != nil {
+		cfg.Metrics = &containerdMetricsConfig{Address: *containerd.MetricsAddress}
+	}
+
+	if containerd.OOMScore != nil {
+		cfg.OOMScore = int(*containerd.OOMScore)
+	}
+
+	if fi.BoolValue(containerd.SystemdCgroup) {
+		cfg.Plugins.CRI.Containerd.DefaultRuntimeName = "runc"
+		cfg.Plugins.CRI.Containerd.Runtimes = map[string]containerdCRIRuntimeConfig{
+			"runc": {
+				RuntimeType: "io.containerd.runc.v2",
+				Options: map[string]interface{}{
+					"SystemdCgroup": true,
+				},
+			},
+		}
+	}
+
+	for _, handler := range containerd.RuntimeHandlers {
+		if cfg.Plugins.CRI.Containerd.Runtimes == nil {
+			cfg.Plugins.CRI.Containerd.Runtimes = make(map[string]containerdCRIRuntimeConfig)
+		}
+
+		runtime := containerdCRIRuntimeConfig{
+			RuntimeType:    handler.Type,
+			RuntimeRoot:    fi.StringValue(handler.Root),
+			PodAnnotations: handler.PodAnnotations,
+			Snapshotter:    fi.StringValue(handler.Snapshotter),
+		}
+
+		// If the user didn't pin their own BinaryName, default it to wherever
+		// buildRuntimeHandlers actually installed the binary for this handler type - otherwise
+		// the shim just searches $PATH for a binary named after the runtime, which isn't where
+		// we put it.
+		binaryName := fi.StringValue(handler.BinaryName)
+		if binaryName == "" {
+			if bin, ok := runtimeHandlerBinaries[handler.Type]; ok && bin.matchesArch(b.Architecture) {
+				binaryName = bin.BinaryPath
+			}
+		}
+		if binaryName != "" {
+			runtime.Options = map[string]interface{}{
+				"BinaryName": binaryName,
+			}
+		}
+
+		cfg.Plugins.CRI.Containerd.Runtimes[handler.Name] = runtime
+	}
+
+	if b.isRootless() {
+		cfg.Plugins.CRI.Containerd.Snapshotter = "fuse-overlayfs"
+	}
+
+	if components.UsesKubenet(b.Cluster.Spec.Networking) {
+		cfg.Plugins.CRI.CNI.ConfTemplate = "/etc/containerd/cni-config.template"
+	}
+
+	b.addRegistryMirrors(&cfg.Plugins.CRI.Registry)
+
+	if containerd.CDI != nil && fi.BoolValue(containerd.CDI.Enabled) {
+		cfg.Plugins.CRI.EnableCDI = true
+		cfg.Plugins.CRI.CDISpecDirs = []string{"/etc/cdi", "/var/run/cdi"}
+	}
+
+	if containerd.NRI != nil && fi.BoolValue(containerd.NRI.Enabled) {
+		cfg.Plugins.NRI = &containerdNRIPluginConfig{
+			Disable:    false,
+			PluginPath: "/opt/nri/plugins",
+			SocketPath: "/var/run/nri/nri.sock",
+		}
+	}
+
+	rendered, err := toml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling containerd config: %v", err)
+	}
+
+	if override := fi.StringValue(containerd.ConfigOverride); override != "" {
+		merged, err := mergeContainerdConfigOverride(rendered, override)
+		if err != nil {
+			return "", fmt.Errorf("error merging containerd ConfigOverride: %v", err)
+		}
+		rendered = []byte(merged)
+	}
+
+	// Always finish through the same Tree.String() serializer, whether or not a ConfigOverride
+	// was merged in above - otherwise the two paths render through two different code paths
+	// (toml.Marshal vs Tree.String), so the shipped file's formatting/key-ordering would
+	// silently change depending only on whether ConfigOverride is set.
+	final, err := normalizeTOML(rendered)
+	if err != nil {
+		return "", fmt.Errorf("error normalizing containerd config: %v", err)
+	}
+
+	return final, nil
+}
+
+// normalizeTOML parses a rendered TOML document and re-serializes it through the Tree type, so
+// callers that may or may not have gone through mergeContainerdConfigOverride still produce
+// output from the identical code path.
+func normalizeTOML(doc []byte) (string, error) {
+	tree, err := toml.LoadBytes(doc)
+	if err != nil {
+		return "", fmt.Errorf("error parsing containerd config: %v", err)
+	}
+	return tree.String(), nil
+}
+
+// mergeContainerdConfigOverride merges a user-supplied ConfigOverride on top of the
+// generated base document. Redefining a TOML table is an error per the spec (and containerd
+// will refuse to load it), so we can't just concatenate the two documents; instead we decode
+// both into generic maps and deep-merge them, with the override's values winning on conflict.
+func mergeContainerdConfigOverride(base []byte, override string) (string, error) {
+	baseTree, err := toml.LoadBytes(base)
+	if err != nil {
+		return "", fmt.Errorf("error parsing generated config: %v", err)
+	}
+
+	overrideTree, err := toml.Load(override)
+	if err != nil {
+		return "", fmt.Errorf("error parsing ConfigOverride: %v", err)
+	}
+
+	merged := mergeTOMLMaps(baseTree.ToMap(), overrideTree.ToMap())
+
+	mergedTree, err := toml.TreeFromMap(merged)
+	if err != nil {
+		return "", fmt.Errorf("error building merged config: %v", err)
+	}
+
+	return mergedTree.String(), nil
+}
+
+// mergeTOMLMaps recursively merges override on top of base: nested tables are merged
+// key-by-key, and any other value (scalar, array, ...) in override replaces the base value.
+func mergeTOMLMaps(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, overrideValue := range override {
+		baseValue, exists := merged[k]
+		baseTable, baseIsTable := baseValue.(map[string]interface{})
+		overrideTable, overrideIsTable := overrideValue.(map[string]interface{})
+
+		if exists && baseIsTable && overrideIsTable {
+			merged[k] = mergeTOMLMaps(baseTable, overrideTable)
+		} else {
+			merged[k] = overrideValue
+		}
+	}
+
+	return merged
+}
+
+// addRegistryMirrors translates Cluster.Spec.ContainerRegistryMirrors into the CRI plugin's
+// registry.mirrors and registry.configs sections.
+//
+// NOTE: the request behind this also asks for the docker builder to honour the same mirror
+// list, but nodeup/pkg/model/docker.go isn't part of this checkout, so that half is not
+// implemented here — this only covers the containerd runtime.
+func (b *ContainerdBuilder) addRegistryMirrors(registry *containerdCRIRegistryConfig) {
+	mirrors := b.Cluster.Spec.ContainerRegistryMirrors
+	if len(mirrors) == 0 {
+		return
+	}
+
+	registry.Mirrors = make(map[string]containerdCRIRegistryMirrorConfig)
+	registry.Configs = make(map[string]containerdCRIRegistryHostConfig)
+
+	for _, mirror := range mirrors {
+		registry.Mirrors[mirror.Host] = containerdCRIRegistryMirrorConfig{
+			Endpoint: mirror.Endpoints,
+		}
+
+		hostConfig := containerdCRIRegistryHostConfig{}
+
+		if fi.StringValue(mirror.CABundle) != "" || fi.BoolValue(mirror.InsecureSkipVerify) {
+			hostConfig.TLS = &containerdCRIRegistryTLSConfig{
+				InsecureSkipVerify: fi.BoolValue(mirror.InsecureSkipVerify),
+			}
+			if fi.StringValue(mirror.CABundle) != "" {
+				hostConfig.TLS.CAFile = registryCAPath(mirror.Host)
+			}
+		}
+
+		if fi.StringValue(mirror.Username) != "" {
+			hostConfig.Auth = &containerdCRIRegistryAuthConfig{
+				Username: fi.StringValue(mirror.Username),
+				Password: fi.StringValue(mirror.Password),
+			}
+		}
+
+		if hostConfig.TLS != nil || hostConfig.Auth != nil {
+			registry.Configs[mirror.Host] = hostConfig
+		}
+	}
+}
+
+// registryCAPath returns the path containerd's registry.configs.<host>.tls.ca_file expects
+// the mirror's CA bundle to be written to.
+func registryCAPath(host string) string {
+	return "/etc/containerd/certs.d/" + host + "/ca.crt"
+}
+
+// sandboxImage returns the CRI sandbox ("pause") image to configure. It honours the cluster's
+// own pod-infra-container-image setting first (so asset mirroring/air-gap configuration is
+// respected), and only falls back to a version-derived default when the user hasn't set one.
+func (b *ContainerdBuilder) sandboxImage() string {
+	if b.Cluster.Spec.Kubelet != nil {
+		if image := fi.StringValue(b.Cluster.Spec.Kubelet.PodInfraContainerImage); image != "" {
+			return image
+		}
+	}
+	return defaultSandboxImage(b.Cluster.Spec.KubernetesVersion)
+}
+
+// defaultSandboxImage picks a pause image appropriate for a Kubernetes version, since newer
+// Kubernetes releases expect a newer pause build (e.g. for IPv6 and zombie-reaping fixes) and a
+// single hardcoded tag eventually becomes wrong or unreachable for clusters pinned to it.
+func defaultSandboxImage(kubernetesVersion string) string {
+	major, minor, ok := parseMajorMinor(kubernetesVersion)
+	switch {
+	case !ok:
+		return "k8s.gcr.io/pause:3.2"
+	case major > 1 || minor >= 24:
+		return "k8s.gcr.io/pause:3.6"
+	case minor >= 21:
+		return "k8s.gcr.io/pause:3.5"
+	case minor >= 20:
+		return "k8s.gcr.io/pause:3.4.1"
+	default:
+		return "k8s.gcr.io/pause:3.2"
+	}
+}
+
+// parseMajorMinor extracts the major/minor version out of a "v1.24.3"-style Kubernetes version
+// string. ok is false if kubernetesVersion is empty or not parseable.
+func parseMajorMinor(kubernetesVersion string) (major int, minor int, ok bool) {
+	parts := strings.SplitN(strings.TrimPrefix(kubernetesVersion, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return major, minor, true
+}
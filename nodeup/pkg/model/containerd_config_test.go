@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestBuildContainerdConfig(t *testing.T) {
+	grid := []struct {
+		name    string
+		cluster *kops.Cluster
+		golden  string
+	}{
+		{
+			name: "default",
+			cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					Containerd: &kops.ContainerdConfig{
+						Version: fi.String("1.3.4"),
+					},
+				},
+			},
+			golden: "containerd-config-default.toml",
+		},
+		{
+			name: "configoverride",
+			cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					Containerd: &kops.ContainerdConfig{
+						Version:        fi.String("1.3.4"),
+						ConfigOverride: fi.String("[plugins.\"io.containerd.grpc.v1.cri\"]\n  sandbox_image = \"my.registry/pause:3.2\"\n"),
+					},
+				},
+			},
+			golden: "containerd-config-override.toml",
+		},
+		{
+			name: "kubenet",
+			cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					Containerd: &kops.ContainerdConfig{
+						Version: fi.String("1.3.4"),
+					},
+					Networking: &kops.NetworkingSpec{
+						Kubenet: &kops.KubenetNetworkingSpec{},
+					},
+				},
+			},
+			golden: "containerd-config-kubenet.toml",
+		},
+		{
+			name: "sandbox-image-override",
+			cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					Containerd:        &kops.ContainerdConfig{Version: fi.String("1.3.4")},
+					KubernetesVersion: "1.24.0",
+					Kubelet: &kops.KubeletConfigSpec{
+						PodInfraContainerImage: fi.String("my.registry/pause:3.9"),
+					},
+				},
+			},
+			golden: "containerd-config-sandbox-image-override.toml",
+		},
+		{
+			name: "sandbox-image-newer-k8s",
+			cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					Containerd:        &kops.ContainerdConfig{Version: fi.String("1.3.4")},
+					KubernetesVersion: "1.24.0",
+				},
+			},
+			golden: "containerd-config-sandbox-image-newer-k8s.toml",
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			b := &ContainerdBuilder{
+				NodeupModelContext: &NodeupModelContext{
+					Cluster: g.cluster,
+				},
+			}
+
+			actual, err := b.buildContainerdConfig()
+			if err != nil {
+				t.Fatalf("buildContainerdConfig failed: %v", err)
+			}
+
+			// A string match alone would happily accept invalid TOML (e.g. a duplicate
+			// table); make sure containerd would actually be able to parse what we wrote.
+			if _, err := toml.Load(actual); err != nil {
+				t.Fatalf("buildContainerdConfig produced invalid TOML: %v\n%s", err, actual)
+			}
+
+			goldenPath := filepath.Join("testdata", g.golden)
+			if os.Getenv("HACK_UPDATE_EXPECTED_IN_PLACE") != "" {
+				if err := ioutil.WriteFile(goldenPath, []byte(actual), 0644); err != nil {
+					t.Fatalf("error writing golden file %q: %v", goldenPath, err)
+				}
+			}
+
+			expectedBytes, err := ioutil.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("error reading golden file %q: %v", goldenPath, err)
+			}
+
+			if actual != string(expectedBytes) {
+				t.Errorf("containerd config did not match golden file %q.\nActual:\n%s\nExpected:\n%s", goldenPath, actual, string(expectedBytes))
+			}
+		})
+	}
+}
+
+func TestDefaultSandboxImage(t *testing.T) {
+	grid := []struct {
+		kubernetesVersion string
+		expected          string
+	}{
+		{"", "k8s.gcr.io/pause:3.2"},
+		{"not-a-version", "k8s.gcr.io/pause:3.2"},
+		{"1.18.0", "k8s.gcr.io/pause:3.2"},
+		{"1.20.0", "k8s.gcr.io/pause:3.4.1"},
+		{"1.21.0", "k8s.gcr.io/pause:3.5"},
+		{"v1.23.5", "k8s.gcr.io/pause:3.5"},
+		{"1.24.0", "k8s.gcr.io/pause:3.6"},
+		{"2.0.0", "k8s.gcr.io/pause:3.6"},
+	}
+
+	for _, g := range grid {
+		t.Run(g.kubernetesVersion, func(t *testing.T) {
+			if actual := defaultSandboxImage(g.kubernetesVersion); actual != g.expected {
+				t.Errorf("defaultSandboxImage(%q) = %q, want %q", g.kubernetesVersion, actual, g.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"k8s.io/kops/nodeup/pkg/distros"
+)
+
+// TestContainerdPackageHashes verifies that the packages we use actually exist, and that
+// the hash is correct, for every architecture we ship. This check is not run by default
+// because it is slow and requires network access; set VERIFY_HASHES=1 to run it, e.g.
+// after adding a new version:
+//
+//	VERIFY_HASHES=1 go test -v ./nodeup/pkg/model -run TestContainerdPackageHashes
+func TestContainerdPackageHashes(t *testing.T) {
+	if os.Getenv("VERIFY_HASHES") == "" {
+		t.Skip("VERIFY_HASHES not set, won't download & verify containerd package hashes")
+	}
+
+	for i := range containerdVersions {
+		dv := &containerdVersions[i]
+		for _, arch := range dv.Architectures {
+			t.Run(fmt.Sprintf("%s-%s", dv.PackageVersion, arch), func(t *testing.T) {
+				verifyPackageHash(t, dv.Source, dv.Hash)
+			})
+		}
+	}
+}
+
+// TestContainerdPackageArchitectures is a fast, offline sanity check that every containerd
+// package declares at least one architecture and that we don't have duplicate entries for
+// the same (version, distro, architecture) tuple.
+func TestContainerdPackageArchitectures(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for i := range containerdVersions {
+		dv := &containerdVersions[i]
+		if len(dv.Architectures) == 0 {
+			t.Errorf("containerdVersions[%d] (%s) does not declare any Architectures", i, dv.PackageVersion)
+		}
+
+		for _, arch := range dv.Architectures {
+			dists := dv.Distros
+			if len(dists) == 0 {
+				dists = []distros.Distribution{""}
+			}
+			for _, d := range dists {
+				key := fmt.Sprintf("%s|%s|%s", dv.PackageVersion, d, arch)
+				if seen[key] {
+					t.Errorf("duplicate containerd package entry for %s", key)
+				}
+				seen[key] = true
+			}
+		}
+	}
+}
+
+// TestRuntimeHandlerBinaryHashes is the runtimeHandlerBinaries analogue of
+// TestContainerdPackageHashes; same VERIFY_HASHES opt-in, same reason (slow, needs network).
+func TestRuntimeHandlerBinaryHashes(t *testing.T) {
+	if os.Getenv("VERIFY_HASHES") == "" {
+		t.Skip("VERIFY_HASHES not set, won't download & verify runtime handler binary hashes")
+	}
+
+	for runtimeType, bin := range runtimeHandlerBinaries {
+		bin := bin
+		t.Run(runtimeType, func(t *testing.T) {
+			verifyPackageHash(t, bin.Source, bin.Hash)
+		})
+		if bin.ShimSource != "" {
+			t.Run(runtimeType+"-shim", func(t *testing.T) {
+				verifyPackageHash(t, bin.ShimSource, bin.ShimHash)
+			})
+		}
+	}
+}
+
+// TestRootlessExtrasHashes is the rootlessExtras analogue of TestContainerdPackageHashes; same
+// VERIFY_HASHES opt-in, same reason (slow, needs network).
+func TestRootlessExtrasHashes(t *testing.T) {
+	if os.Getenv("VERIFY_HASHES") == "" {
+		t.Skip("VERIFY_HASHES not set, won't download & verify rootless-extras hashes")
+	}
+
+	for version, extras := range rootlessExtras {
+		extras := extras
+		t.Run(version, func(t *testing.T) {
+			verifyPackageHash(t, extras.Source, extras.Hash)
+		})
+	}
+}
+
+// TestRuncBinaryHashes is the runcBinaries analogue of TestContainerdPackageHashes; same
+// VERIFY_HASHES opt-in, same reason (slow, needs network).
+func TestRuncBinaryHashes(t *testing.T) {
+	if os.Getenv("VERIFY_HASHES") == "" {
+		t.Skip("VERIFY_HASHES not set, won't download & verify runc binary hashes")
+	}
+
+	for arch, runc := range runcBinaries {
+		runc := runc
+		t.Run(string(arch), func(t *testing.T) {
+			verifyPackageHash(t, runc.Source, runc.Hash)
+		})
+	}
+}
+
+// TestNoPlaceholderHashes is a fast, offline check - unlike the VERIFY_HASHES-gated tests above,
+// it always runs - that every hash we ship has actually been confirmed against the real
+// artifact. A hash still set to placeholderHash means nobody has run the corresponding
+// VERIFY_HASHES test yet, which would otherwise ship a binary nodeup can't install (it fails
+// hash verification at download time).
+func TestNoPlaceholderHashes(t *testing.T) {
+	for i := range containerdVersions {
+		dv := &containerdVersions[i]
+		if dv.Hash == placeholderHash {
+			t.Errorf("containerdVersions[%d] (%s) still has a placeholder hash", i, dv.PackageVersion)
+		}
+	}
+
+	for arch, runc := range runcBinaries {
+		if runc.Hash == placeholderHash {
+			t.Errorf("runcBinaries[%s] still has a placeholder hash", arch)
+		}
+	}
+
+	for runtimeType, bin := range runtimeHandlerBinaries {
+		if bin.Hash == placeholderHash {
+			t.Errorf("runtimeHandlerBinaries[%q] still has a placeholder hash", runtimeType)
+		}
+		if bin.ShimSource != "" && bin.ShimHash == placeholderHash {
+			t.Errorf("runtimeHandlerBinaries[%q] still has a placeholder shim hash", runtimeType)
+		}
+	}
+
+	for version, extras := range rootlessExtras {
+		if extras.Hash == placeholderHash {
+			t.Errorf("rootlessExtras[%q] still has a placeholder hash", version)
+		}
+	}
+}
+
+func verifyPackageHash(t *testing.T, url string, expected string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("error fetching %q: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	hasher := sha1.New()
+	if _, err := io.Copy(hasher, resp.Body); err != nil {
+		t.Fatalf("error reading %q: %v", url, err)
+	}
+
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	if actual != expected {
+		t.Errorf("hash mismatch for %q: actual=%q expected=%q", url, actual, expected)
+	}
+}